@@ -0,0 +1,174 @@
+package main // Define the main package, shared with main.go
+
+import (
+	"log"      // Offers logging capabilities to standard output or error streams
+	"net/http" // Allows interaction with HTTP clients and servers
+	"net/url"  // Provides URL parsing, encoding, and query manipulation
+	"strings"  // Contains utilities for string manipulation
+	"sync"     // Provides mutexes for protecting shared state across goroutines
+	"time"     // Contains time-related functionality such as sleeping or timeouts
+
+	"golang.org/x/net/html" // Provides a streaming HTML tokenizer for link extraction
+)
+
+// Crawler walks linked HTML pages starting from one or more seed URLs and
+// collects every PDF link it finds along the way.
+type Crawler struct {
+	MaxDepth       int             // How many link-hops to follow away from a seed URL
+	SameDomainOnly bool            // If true, never follow links that leave the seed's domain
+	Delay          time.Duration   // Minimum time to wait between two fetches
+	Robots         *RobotsPolicy   // Consulted before every fetch; nil means no robots.txt enforcement
+	UserAgent      string          // Identifies the crawler to the sites it fetches from
+	From           string          // Contact address operators can use to reach us, per RFC 7231 §5.5.1
+	client         *http.Client    // HTTP client reused across every fetch
+	mu             sync.Mutex      // Guards visited against concurrent access
+	visited        map[string]bool // Set of URLs already fetched, to avoid cycles
+}
+
+// NewCrawler builds a Crawler ready to discover PDFs up to maxDepth hops away
+// from its seed URLs, optionally restricted to the seed's own domain. userAgent
+// and from are sent with every page fetch, matching ConfigureHTTPFetcher's
+// headers for the PDF downloads themselves.
+func NewCrawler(maxDepth int, sameDomainOnly bool, delay time.Duration, robots *RobotsPolicy, userAgent, from string) *Crawler {
+	return &Crawler{
+		MaxDepth:       maxDepth,
+		SameDomainOnly: sameDomainOnly,
+		Delay:          delay,
+		Robots:         robots,
+		UserAgent:      userAgent,
+		From:           from,
+		client:         &http.Client{Timeout: 3 * time.Minute},
+		visited:        make(map[string]bool),
+	}
+}
+
+// markVisited records uri as visited and reports whether it was new.
+func (c *Crawler) markVisited(uri string) bool {
+	c.mu.Lock()         // Protect the visited set from concurrent writers
+	defer c.mu.Unlock() // Always release the lock before returning
+	if c.visited[uri] { // Already seen this exact URL
+		return false
+	}
+	c.visited[uri] = true // Mark it seen for next time
+	return true
+}
+
+// Crawl performs a breadth-first search from seedURL, returning every
+// absolute `.pdf` link discovered within MaxDepth hops of HTML pages.
+func (c *Crawler) Crawl(seedURL string) []string {
+	seed, err := url.Parse(seedURL) // Parse the seed so we know its domain
+	if err != nil {
+		log.Printf("Invalid seed URL %s: %v", seedURL, err)
+		return nil
+	}
+
+	type queued struct {
+		uri   *url.URL // Page to fetch
+		depth int      // Hops away from the seed
+	}
+
+	var pdfLinks []string                    // Every PDF link discovered so far
+	queue := []queued{{uri: seed, depth: 0}} // BFS queue, seeded with the starting page
+
+	for len(queue) > 0 { // Keep going until there is nothing left to visit
+		current := queue[0] // Pop the front of the queue
+		queue = queue[1:]
+
+		if !c.markVisited(current.uri.String()) { // Skip pages we've already fetched
+			continue
+		}
+		if c.SameDomainOnly && current.uri.Hostname() != seed.Hostname() { // Enforce domain scoping
+			continue
+		}
+		if c.Robots != nil && !c.Robots.Allowed(current.uri.String()) { // Respect robots.txt
+			log.Printf("Blocked by robots.txt, skipping: %s", current.uri)
+			continue
+		}
+
+		delay := c.Delay
+		if c.Robots != nil { // A published Crawl-delay can only ever slow us down further
+			if crawlDelay := c.Robots.CrawlDelay(current.uri.String()); crawlDelay > delay {
+				delay = crawlDelay
+			}
+		}
+		if delay > 0 { // Throttle requests so we don't hammer the remote host
+			time.Sleep(delay)
+		}
+
+		links, err := c.fetchLinks(current.uri) // Fetch the page and extract every link on it
+		if err != nil {
+			log.Printf("Failed to crawl %s: %v", current.uri, err)
+			continue
+		}
+
+		for _, link := range links { // Classify each discovered link
+			if link.Scheme != "http" && link.Scheme != "https" { // Never collect or follow a crawled non-http(s) link
+				continue
+			}
+			if strings.HasSuffix(strings.ToLower(link.Path), ".pdf") {
+				if c.SameDomainOnly && link.Hostname() != seed.Hostname() { // Same-domain scoping applies to PDF links too, not just recursion
+					continue
+				}
+				pdfLinks = append(pdfLinks, link.String())
+				continue
+			}
+			if current.depth < c.MaxDepth { // Only recurse into HTML pages while depth allows it
+				queue = append(queue, queued{uri: link, depth: current.depth + 1})
+			}
+		}
+	}
+
+	return removeDuplicatesFromSlice(pdfLinks) // De-duplicate before handing back to the caller
+}
+
+// fetchLinks downloads pageURL and resolves every <a href> on it against
+// pageURL, returning the resulting absolute URLs.
+func (c *Crawler) fetchLinks(pageURL *url.URL) ([]*url.URL, error) {
+	req, err := http.NewRequest(http.MethodGet, pageURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	if c.From != "" {
+		req.Header.Set("From", c.From)
+	}
+
+	resp, err := c.client.Do(req) // Fetch the page
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() // Always release the response body
+
+	if resp.StatusCode != http.StatusOK { // Only HTML pages we can actually read are useful
+		return nil, nil
+	}
+	if !strings.Contains(resp.Header.Get("Content-Type"), "text/html") { // Skip non-HTML bodies
+		return nil, nil
+	}
+
+	var links []*url.URL
+	tokenizer := html.NewTokenizer(resp.Body) // Stream-tokenize the HTML body
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return links, nil // io.EOF (or any other error) ends tokenization
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			if token.Data != "a" { // Only <a> tags carry the links we care about
+				continue
+			}
+			for _, attr := range token.Attr {
+				if attr.Key != "href" {
+					continue
+				}
+				resolved, err := pageURL.Parse(attr.Val) // Resolve relative to the current page
+				if err != nil {
+					continue
+				}
+				links = append(links, resolved)
+			}
+		}
+	}
+}
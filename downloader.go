@@ -0,0 +1,187 @@
+package main // Define the main package, shared with main.go
+
+import (
+	"log"  // Offers logging capabilities to standard output or error streams
+	"sync" // Provides mutexes, wait groups, and condition variables for coordinating goroutines
+	"time" // Contains time-related functionality such as sleeping or timeouts
+)
+
+// maxInFlightPerHost caps how many requests to a single host the pool will
+// ever have outstanding at once, regardless of how many workers are free
+// globally. This is deliberately tighter than global concurrency so a burst
+// of URLs on one domain can't starve every worker's rate limiter at once.
+const maxInFlightPerHost = 4
+
+// DownloadResult reports the outcome of downloading a single URL, for
+// callers that want to aggregate a final report once the pool drains.
+type DownloadResult struct {
+	URL      string        // The URL that was downloaded
+	Bytes    int64         // Number of bytes written to disk, zero on failure
+	Duration time.Duration // Wall-clock time spent on the final attempt
+	Err      error         // Non-nil if every attempt (including retries) failed
+}
+
+// DownloadPool fetches many URLs concurrently while staying polite to any
+// single host: a global worker limit bounds total concurrency, a per-host
+// rate limiter throttles requests/sec, and a per-host in-flight counter
+// caps how many simultaneous requests one domain can see.
+type DownloadPool struct {
+	Concurrency       int       // Number of worker goroutines pulling from the job queue
+	RequestsPerSecond float64   // Allowed requests per second, per host
+	MaxRetries        int       // Retries attempted after a retryable failure
+	Manifest          *Manifest // Tracks what's already been fetched, shared across workers
+
+	limiterMu sync.Mutex              // Guards limiters
+	limiters  map[string]*hostLimiter // One token-bucket limiter per host
+
+	inFlightMu   sync.Mutex     // Guards inFlight, paired with inFlightCond
+	inFlightCond *sync.Cond     // Wakes waiters in beginInFlight when a slot frees up
+	inFlight     map[string]int // Number of requests currently in progress per host
+}
+
+// NewDownloadPool builds a DownloadPool with the given global concurrency,
+// per-host rate limit, and retry budget.
+func NewDownloadPool(concurrency int, requestsPerSecondPerHost float64, maxRetries int, manifest *Manifest) *DownloadPool {
+	pool := &DownloadPool{
+		Concurrency:       concurrency,
+		RequestsPerSecond: requestsPerSecondPerHost,
+		MaxRetries:        maxRetries,
+		Manifest:          manifest,
+		limiters:          make(map[string]*hostLimiter),
+		inFlight:          make(map[string]int),
+	}
+	pool.inFlightCond = sync.NewCond(&pool.inFlightMu)
+	return pool
+}
+
+// Run downloads every url in urls using a fixed-size worker pool, returning
+// one DownloadResult per URL once all workers have finished.
+func (p *DownloadPool) Run(urls []string, outputDir string) []DownloadResult {
+	jobs := make(chan string, len(urls))            // Buffered so producers never block
+	results := make(chan DownloadResult, len(urls)) // Buffered so workers never block on send
+
+	var wg sync.WaitGroup
+	for i := 0; i < p.Concurrency; i++ { // Start a fixed pool of workers
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for url := range jobs { // Pull URLs until the channel is closed and drained
+				results <- p.downloadWithRetry(url, outputDir)
+			}
+		}()
+	}
+
+	for _, url := range urls { // Enqueue every URL for the workers to pick up
+		jobs <- url
+	}
+	close(jobs) // No more work is coming; workers exit once the queue drains
+
+	wg.Wait()      // Block until every worker has finished
+	close(results) // Safe to close now that nothing else writes to it
+
+	collected := make([]DownloadResult, 0, len(urls))
+	for result := range results { // Drain the results channel into a slice
+		collected = append(collected, result)
+	}
+	return collected
+}
+
+// downloadWithRetry attempts to fetch url, retrying with exponential backoff
+// as long as the failure looks transient and retries remain.
+func (p *DownloadPool) downloadWithRetry(finalURL, outputDir string) DownloadResult {
+	host := getDomainFromURL(finalURL) // Per-host limiter/counter key
+	var lastErr error
+
+	for attempt := 0; attempt <= p.MaxRetries; attempt++ {
+		p.limiterFor(host).wait() // Respect the per-host rate limit before every attempt
+		p.beginInFlight(host)     // Track this request against the host's in-flight counter
+
+		start := time.Now()
+		result := fetchAndSavePDF(finalURL, outputDir, p.Manifest)
+		elapsed := time.Since(start)
+
+		p.endInFlight(host) // Release the in-flight slot regardless of outcome
+
+		if result.Err == nil {
+			return DownloadResult{URL: finalURL, Bytes: result.Written, Duration: elapsed}
+		}
+
+		lastErr = result.Err
+		if !result.Retryable || attempt == p.MaxRetries { // Give up on permanent failures or exhausted retries
+			break
+		}
+
+		backoff := time.Duration(1<<attempt) * time.Second // 1s, 2s, 4s, ...
+		log.Printf("Retrying %s after %v (attempt %d/%d): %v", finalURL, backoff, attempt+1, p.MaxRetries, result.Err)
+		time.Sleep(backoff)
+	}
+
+	return DownloadResult{URL: finalURL, Err: lastErr}
+}
+
+// beginInFlight blocks until fewer than maxInFlightPerHost requests to host
+// are outstanding, then records that a request has started.
+func (p *DownloadPool) beginInFlight(host string) {
+	p.inFlightMu.Lock()
+	defer p.inFlightMu.Unlock()
+	for p.inFlight[host] >= maxInFlightPerHost { // Wait for a slot to free up
+		p.inFlightCond.Wait()
+	}
+	p.inFlight[host]++
+}
+
+// endInFlight records that a request to host has finished and wakes any
+// workers waiting for a free slot on that host.
+func (p *DownloadPool) endInFlight(host string) {
+	p.inFlightMu.Lock()
+	p.inFlight[host]--
+	p.inFlightMu.Unlock()
+	p.inFlightCond.Broadcast()
+}
+
+// limiterFor returns the token-bucket limiter for host, creating one on
+// first use.
+func (p *DownloadPool) limiterFor(host string) *hostLimiter {
+	p.limiterMu.Lock()
+	defer p.limiterMu.Unlock()
+	limiter, ok := p.limiters[host]
+	if !ok {
+		limiter = newHostLimiter(p.RequestsPerSecond)
+		p.limiters[host] = limiter
+	}
+	return limiter
+}
+
+// hostLimiter is a simple token-bucket rate limiter scoped to a single host.
+type hostLimiter struct {
+	mu         sync.Mutex
+	ratePerSec float64   // Tokens added per second
+	tokens     float64   // Tokens currently available
+	lastRefill time.Time // Last time tokens were topped up
+}
+
+// newHostLimiter builds a limiter starting with a full bucket of one token.
+func newHostLimiter(ratePerSec float64) *hostLimiter {
+	return &hostLimiter{ratePerSec: ratePerSec, tokens: 1, lastRefill: time.Now()}
+}
+
+// wait blocks until a token is available, then consumes it.
+func (h *hostLimiter) wait() {
+	for {
+		h.mu.Lock()
+		now := time.Now()
+		h.tokens += now.Sub(h.lastRefill).Seconds() * h.ratePerSec // Refill based on elapsed time
+		if h.tokens > 1 {
+			h.tokens = 1 // Cap the bucket so bursts can't outrun the configured rate for long
+		}
+		h.lastRefill = now
+
+		if h.tokens >= 1 {
+			h.tokens-- // Consume a token and proceed
+			h.mu.Unlock()
+			return
+		}
+		h.mu.Unlock()
+		time.Sleep(time.Duration(float64(time.Second) / h.ratePerSec))
+	}
+}
@@ -0,0 +1,187 @@
+package main // Define the main package, shared with main.go
+
+import (
+	"context"       // Lets callers bound or cancel an in-flight fetch
+	"errors"        // Lets us define a sentinel error for "not modified" responses
+	"fmt"           // Formats canonical URLs and error messages
+	"io"            // Defines basic interfaces to I/O primitives, like Reader and Writer
+	"net/http"      // Allows interaction with HTTP clients and servers
+	"net/url"       // Provides URL parsing, encoding, and query manipulation
+	"os"            // Gives access to OS features, such as file and directory operations
+	"path/filepath" // Offers functions to handle file paths in a way compatible with the OS
+	"strings"       // Contains utilities for string manipulation
+	"time"          // Contains time-related functionality such as sleeping or timeouts
+)
+
+// ErrNotModified is returned by a Fetcher when the caller's conditional
+// headers (If-None-Match / If-Modified-Since) indicate nothing has changed.
+var ErrNotModified = errors.New("fetcher: not modified")
+
+// FetchError reports a non-2xx response from a Fetcher, carrying enough
+// detail for a caller to decide whether retrying is worthwhile.
+type FetchError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *FetchError) Error() string {
+	return e.Status
+}
+
+// Fetcher retrieves the bytes behind a URL, regardless of scheme. headers
+// carries any request headers the caller wants honored (Range, If-None-Match,
+// ...); implementations that can't make use of them are free to ignore them.
+// The returned header carries whatever response metadata the scheme has to
+// offer (Content-Type, Content-Range, ETag, ...).
+type Fetcher interface {
+	Fetch(ctx context.Context, rawURL string, headers http.Header) (io.ReadCloser, http.Header, error)
+}
+
+// fetcherRegistry maps a URL scheme to the Fetcher that handles it.
+var fetcherRegistry = map[string]Fetcher{}
+
+func init() {
+	httpFetcher := &httpFetcher{client: &http.Client{Timeout: 3 * time.Minute}}
+	fetcherRegistry["http"] = httpFetcher
+	fetcherRegistry["https"] = httpFetcher
+	fetcherRegistry["file"] = fileFetcher{}
+	fetcherRegistry["github"] = githubFetcher{raw: httpFetcher}
+}
+
+// ConfigureHTTPFetcher sets the User-Agent and From headers sent with every
+// http(s) (and github://, which delegates to it) request.
+func ConfigureHTTPFetcher(userAgent, from string) {
+	fetcher := fetcherRegistry["https"].(*httpFetcher)
+	fetcher.UserAgent = userAgent
+	fetcher.From = from
+}
+
+// fetcherFor looks up the registered Fetcher for rawURL's scheme.
+func fetcherFor(rawURL string) (Fetcher, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	fetcher, ok := fetcherRegistry[parsed.Scheme]
+	if !ok {
+		return nil, fmt.Errorf("no fetcher registered for scheme %q", parsed.Scheme)
+	}
+	return fetcher, nil
+}
+
+// httpFetcher fetches over plain HTTP(S), forwarding any caller-supplied
+// headers (Range, conditional GET headers) straight through.
+type httpFetcher struct {
+	client    *http.Client
+	UserAgent string // Identifies the crawler to the sites it fetches from
+	From      string // Contact address operators can use to reach us, per RFC 7231 §5.5.1
+}
+
+func (f *httpFetcher) Fetch(ctx context.Context, rawURL string, headers http.Header) (io.ReadCloser, http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header = headers.Clone()
+	if f.UserAgent != "" {
+		req.Header.Set("User-Agent", f.UserAgent)
+	}
+	if f.From != "" {
+		req.Header.Set("From", f.From)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		return nil, resp.Header, ErrNotModified
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		resp.Body.Close()
+		return nil, resp.Header, &FetchError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	return resp.Body, resp.Header, nil
+}
+
+// fileFetcher reads a PDF straight off local disk, resolving symlinks first
+// so a mirror of symlinked documents behaves the same as a real copy.
+type fileFetcher struct{}
+
+func (fileFetcher) Fetch(_ context.Context, rawURL string, _ http.Header) (io.ReadCloser, http.Header, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resolved, err := filepath.EvalSymlinks(parsed.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := os.Open(resolved)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/pdf")
+	header.Set("Content-Length", fmt.Sprintf("%d", info.Size()))
+	return file, header, nil
+}
+
+// githubFetcher serves `github://owner/repo/path@ref` URLs by translating
+// them to their raw.githubusercontent.com equivalent and delegating to the
+// HTTP fetcher.
+type githubFetcher struct {
+	raw Fetcher
+}
+
+func (g githubFetcher) Fetch(ctx context.Context, rawURL string, headers http.Header) (io.ReadCloser, http.Header, error) {
+	canonical, err := convertGitHubURL(rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	return g.raw.Fetch(ctx, canonical, headers)
+}
+
+// convertGitHubURL expands `github://owner/repo/path@ref` (ref defaults to
+// "main") into its canonical raw.githubusercontent.com HTTPS URL.
+func convertGitHubURL(rawURL string) (string, error) {
+	trimmed := strings.TrimPrefix(rawURL, "github://")
+
+	ref := "main"
+	pathPart := trimmed
+	if atIdx := strings.LastIndex(trimmed, "@"); atIdx != -1 {
+		pathPart, ref = trimmed[:atIdx], trimmed[atIdx+1:]
+	}
+
+	parts := strings.SplitN(pathPart, "/", 3)
+	if len(parts) < 3 {
+		return "", fmt.Errorf("invalid github:// URL, expected owner/repo/path: %s", rawURL)
+	}
+	owner, repo, filePath := parts[0], parts[1], parts[2]
+
+	return fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/%s", owner, repo, ref, filePath), nil
+}
+
+// ConvertURL expands a short scheme prefix (currently `github://`) into its
+// canonical HTTPS form, so validation and domain-scoping logic downstream
+// only ever has to deal with real http(s) URLs. Anything else passes through
+// unchanged.
+func ConvertURL(rawURL string) string {
+	if strings.HasPrefix(rawURL, "github://") {
+		if canonical, err := convertGitHubURL(rawURL); err == nil {
+			return canonical
+		}
+	}
+	return rawURL
+}
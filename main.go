@@ -2,7 +2,12 @@ package main // Define the main package, the starting point for Go executables
 
 import (
 	"bufio"
-	"bytes"         // Provides functionality for manipulating byte slices and buffers
+	"context"       // Bounds the lifetime of a single fetch dispatched through a Fetcher
+	"crypto/sha256" // Computes a streaming digest of each PDF as it's downloaded
+	"encoding/hex"  // Renders the digest as the hex string we persist alongside each file
+	"errors"        // Lets us construct simple sentinel errors for non-2xx / unexpected responses
+	"flag"          // Parses command-line flags for the User-Agent and From headers
+	"hash"          // Names the hash.Hash interface used to prime a resumed download's digest
 	"io"            // Defines basic interfaces to I/O primitives, like Reader and Writer
 	"log"           // Offers logging capabilities to standard output or error streams
 	"net/http"      // Allows interaction with HTTP clients and servers
@@ -11,11 +16,19 @@ import (
 	"path"          // Provides functions for manipulating slash-separated paths (not OS specific)
 	"path/filepath" // Offers functions to handle file paths in a way compatible with the OS
 	"regexp"        // Supports regular expression handling using RE2 syntax
+	"strconv"       // Formats the byte offset used in a resumable Range header
 	"strings"       // Contains utilities for string manipulation
 	"time"          // Contains time-related functionality such as sleeping or timeouts
 )
 
 func main() {
+	userAgent := flag.String("user-agent", "nsh-usa-com-documentation-bot/1.0", "User-Agent header sent with every request")
+	from := flag.String("from", "", "Contact address sent via the From header (optional)")
+	flag.Parse()
+
+	ConfigureHTTPFetcher(*userAgent, *from) // Apply the configured headers to every http(s) fetch
+	robots := NewRobotsPolicy(*userAgent)   // Consulted before crawling or downloading any URL
+
 	pdfOutputDir := "PDFs/" // Directory path where downloaded PDFs will be stored
 	// Check if the PDF output directory exists using helper function
 	if !directoryExists(pdfOutputDir) {
@@ -24,17 +37,65 @@ func main() {
 	}
 	// Read the local file containing the list of URLs to scrape
 	finalPDFList := readAppendLineByLine("valid_pdf.txt") // Read URLs from "pdfs.txt" into a slice
+
+	// Crawl the site starting from its homepage to discover any PDFs that
+	// valid_pdf.txt hasn't been updated with yet
+	seedURLs := []string{"https://www.klnsh-usaueber.com"}                          // Starting points for the crawl
+	crawler := NewCrawler(3, true, 500*time.Millisecond, robots, *userAgent, *from) // Stay within the domain, 3 hops deep, throttled
+	for _, seed := range seedURLs {                                                 // Crawl every configured seed
+		finalPDFList = append(finalPDFList, crawler.Crawl(seed)...) // Merge discovered PDF links in
+	}
+
+	// Pull in any PDFs published through the site's sitemap too, since a
+	// sitemap often lists documents the crawler's link-following won't reach
+	sitemapSeeds := []string{"https://www.klnsh-usaueber.com/sitemap.xml"}
+	for _, sitemap := range sitemapSeeds {
+		finalPDFList = append(finalPDFList, FetchSitemapURLs(sitemap)...)
+	}
+
+	// Some collections are only published as IIIF-style JSON manifests rather
+	// than HTML pages or a sitemap, so ingest those too
+	manifestSeeds := []string{"https://www.klnsh-usaueber.com/manifest.json"}
+	for _, manifestURL := range manifestSeeds {
+		finalPDFList = append(finalPDFList, FetchManifestURLs(manifestURL)...)
+	}
+
 	finalPDFList = removeDuplicatesFromSlice(finalPDFList) // Remove duplicate entries from slice
-	remoteDomain := "https://www.klnsh-usaueber.com"                         // Define base domain for relative links
+	remoteDomain := "https://www.klnsh-usaueber.com"       // Define base domain for relative links
+
+	var downloadURLs []string           // Absolute, validated URLs ready to hand to the download pool
 	for _, urls := range finalPDFList { // Loop through all cleaned and unique PDF links
-		domain := getDomainFromURL(urls) // Extract domain from each URL to check if it's relative or absolute
-		if domain == "" {
+		urls = ConvertURL(urls)           // Expand any github:// (etc.) short prefix to a canonical https URL
+		if getSchemeFromURL(urls) == "" { // No scheme at all means this is a bare relative path
 			urls = remoteDomain + urls // If relative, prepend base domain
 		}
-		if isUrlValid(urls) { // Ensure URL is syntactically valid
-			downloadPDF(urls, pdfOutputDir) // Download the PDF and save it to disk
+		if !isUrlValid(urls) { // Ensure URL is syntactically valid
+			continue
+		}
+		if !robots.Allowed(urls) { // Respect robots.txt before ever queuing a download
+			log.Printf("Blocked by robots.txt, skipping: %s", urls)
+			continue
+		}
+		downloadURLs = append(downloadURLs, urls) // Queue it for concurrent download
+	}
+
+	// Load the manifest recording what we've already fetched, so unchanged
+	// files can be skipped on re-runs instead of re-downloaded from scratch
+	manifest := LoadManifest(filepath.Join(pdfOutputDir, "manifest.json"))
+
+	// Fan the queued URLs out across a worker pool instead of downloading one
+	// at a time, so thousands of PDFs don't take hours to fetch sequentially
+	pool := NewDownloadPool(8, 2, 3, manifest)      // 8 workers, 2 req/sec per host, 3 retries on transient errors
+	results := pool.Run(downloadURLs, pdfOutputDir) // Blocks until every URL has been attempted
+	var succeeded, failed int                       // Tally up a final report
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			continue
 		}
+		succeeded++
 	}
+	log.Printf("Download pool finished: %d succeeded, %d failed, %d total", succeeded, failed, len(results))
 }
 
 // Read and append the file line by line to a slice.
@@ -67,6 +128,17 @@ func getDomainFromURL(rawURL string) string {
 	return host                  // Return extracted domain name
 }
 
+// Extract the scheme from a URL string (like "https" or "file"), empty for
+// a bare relative path such as "/docs/file.pdf"
+func getSchemeFromURL(rawURL string) string {
+	parsedURL, err := url.Parse(rawURL) // Parse URL into components
+	if err != nil {                     // Handle parsing error
+		log.Println(err) // Log the error
+		return ""        // Return empty string to indicate invalid URL
+	}
+	return parsedURL.Scheme // Return the extracted scheme
+}
+
 // Extracts and returns the base name (file name) from the URL path
 func getFileNameOnly(content string) string {
 	return path.Base(content) // Return last segment of the path
@@ -119,61 +191,159 @@ func fileExists(filename string) bool {
 	return !info.IsDir() // Return true only if it's not a directory
 }
 
-// Downloads and writes a PDF file from the URL to the specified directory
-func downloadPDF(finalURL, outputDir string) bool {
+// downloadAttempt carries the outcome of a single fetch-and-save attempt,
+// including enough detail for a caller to decide whether retrying makes sense.
+type downloadAttempt struct {
+	Written    int64 // Number of bytes written to disk, zero if nothing was saved
+	StatusCode int   // HTTP status code returned by the server, zero if the request never completed
+	Retryable  bool  // True if the failure looks transient (timeout, 5xx, connection reset)
+	Err        error // Non-nil on any failure, including a deliberate skip
+}
+
+// fetchAndSavePDF performs a single download attempt for finalURL, saving the
+// result under outputDir. It streams a SHA-256 digest alongside the bytes
+// as they arrive, resumes from a `.part` file via a Range request when one
+// exists, and consults manifest to skip files that haven't changed since the
+// last run. It is the core used by the worker pool's retry logic.
+func fetchAndSavePDF(finalURL, outputDir string, manifest *Manifest) downloadAttempt {
 	filename := strings.ToLower(urlToFilename(finalURL)) // Generate sanitized filename
 	filePath := filepath.Join(outputDir, filename)       // Build full path
+	partPath := filePath + ".part"                       // Temporary file written to while downloading
 
 	if fileExists(filePath) { // Skip if already downloaded
 		log.Printf("File already exists, skipping: %s", filePath)
-		return false
+		return downloadAttempt{}
 	}
 
-	client := &http.Client{Timeout: 3 * time.Minute} // Create HTTP client with 3-minute timeout to avoid hanging
+	fetcher, err := fetcherFor(finalURL) // Resolve the right Fetcher for this URL's scheme
+	if err != nil {
+		log.Printf("Failed to download %s: %v", finalURL, err)
+		return downloadAttempt{Err: err}
+	}
+
+	headers := http.Header{}
+	// Only trust the manifest's ETag/Last-Modified when we actually still hold
+	// the bytes they describe; otherwise a 304 would leave nothing on disk
+	// and we'd never retry, since the manifest entry alone would look like a
+	// successful download forever.
+	if fileExists(filePath) || fileExists(partPath) {
+		if entry, ok := manifest.Get(finalURL); ok { // We've fetched this URL before; ask the server if it's changed
+			if entry.ETag != "" {
+				headers.Set("If-None-Match", entry.ETag)
+			}
+			if entry.LastModified != "" {
+				headers.Set("If-Modified-Since", entry.LastModified)
+			}
+		}
+	}
+
+	hasher := sha256.New() // Accumulates the digest as bytes stream in
+	var resumeOffset int64
+	if partInfo, err := os.Stat(partPath); err == nil { // A previous attempt left bytes on disk
+		resumeOffset = partInfo.Size()
+		if err := primeHashFromFile(partPath, hasher); err != nil {
+			log.Printf("Failed to re-hash partial download %s, restarting: %v", partPath, err)
+			resumeOffset = 0
+			hasher.Reset()
+		} else {
+			headers.Set("Range", "bytes="+strconv.FormatInt(resumeOffset, 10)+"-")
+		}
+	}
 
-	resp, err := client.Get(finalURL) // Perform HTTP GET request to download the file
-	if err != nil {                   // Check if an error occurred during request
-		log.Printf("Failed to download %s: %v", finalURL, err) // Log the error with context
-		return false                                           // Exit function if request failed
+	body, respHeaders, err := fetcher.Fetch(context.Background(), finalURL, headers) // Perform the fetch
+	if errors.Is(err, ErrNotModified) {                                              // Our copy is still current
+		log.Printf("Not modified, skipping: %s", finalURL)
+		return downloadAttempt{}
+	}
+	if fetchErr, ok := err.(*FetchError); ok { // Scheme reported a non-2xx status
+		log.Printf("Download failed for %s: %s", finalURL, fetchErr.Status)
+		return downloadAttempt{
+			StatusCode: fetchErr.StatusCode,
+			Retryable:  fetchErr.StatusCode >= 500, // 5xx is usually transient; 4xx will never succeed on retry
+			Err:        fetchErr,
+		}
+	}
+	if err != nil { // Any other failure (network, local filesystem, ...) is worth retrying
+		log.Printf("Failed to download %s: %v", finalURL, err)
+		return downloadAttempt{Retryable: true, Err: err}
 	}
-	defer resp.Body.Close() // Ensure the response body is closed after reading
+	defer body.Close() // Ensure the response body is closed after reading
 
-	if resp.StatusCode != http.StatusOK { // Check for HTTP 200 OK status
-		log.Printf("Download failed for %s: %s", finalURL, resp.Status) // Log failure reason
-		return false                                                    // Exit if status is not OK
+	partial := respHeaders.Get("Content-Range") != "" // Only a true 206 response carries this header
+	if resumeOffset > 0 && !partial {                 // The fetcher ignored our Range header; start over
+		log.Printf("Resuming %s is not supported, restarting from zero", finalURL)
+		resumeOffset = 0
+		hasher.Reset()
 	}
 
-	contentType := resp.Header.Get("Content-Type")         // Retrieve the content type from HTTP headers
+	contentType := respHeaders.Get("Content-Type")         // Retrieve the content type from the fetcher's headers
 	if !strings.Contains(contentType, "application/pdf") { // Ensure it's a PDF
 		log.Printf("Invalid content type for %s: %s (expected application/pdf)", finalURL, contentType)
-		return false // Skip if it's not a PDF
+		return downloadAttempt{Err: errors.New("unexpected content type")}
 	}
 
-	var buf bytes.Buffer                     // Create buffer to temporarily hold the file data
-	written, err := io.Copy(&buf, resp.Body) // Copy response body into buffer
-	if err != nil {                          // Handle error while reading response
+	partFlags := os.O_WRONLY | os.O_CREATE // Fresh download: truncate any stale partial file
+	if resumeOffset > 0 {
+		partFlags = os.O_WRONLY | os.O_APPEND // Resuming: keep the bytes already on disk
+	} else {
+		partFlags |= os.O_TRUNC
+	}
+	partFile, err := os.OpenFile(partPath, partFlags, 0o644)
+	if err != nil {
+		log.Printf("Failed to open partial file for %s: %v", finalURL, err)
+		return downloadAttempt{Err: err}
+	}
+
+	written, err := io.Copy(io.MultiWriter(partFile, hasher), body) // Stream straight to disk and the digest
+	closeErr := partFile.Close()
+	if err != nil { // A body read failure partway through is usually transient
 		log.Printf("Failed to read PDF data from %s: %v", finalURL, err)
-		return false
+		return downloadAttempt{Retryable: true, Err: err}
 	}
-	if written == 0 { // If nothing was read (empty file)
+	if closeErr != nil {
+		log.Printf("Failed to close partial file for %s: %v", finalURL, closeErr)
+		return downloadAttempt{Err: closeErr}
+	}
+
+	totalSize := resumeOffset + written
+	if totalSize == 0 { // If nothing was read (empty file)
 		log.Printf("Downloaded 0 bytes for %s; not creating file", finalURL)
-		return false
+		return downloadAttempt{Err: errors.New("empty response body")}
 	}
 
-	out, err := os.Create(filePath) // Create file on disk at the specified location
-	if err != nil {                 // Handle file creation error
-		log.Printf("Failed to create file for %s: %v", finalURL, err)
-		return false
+	if err := os.Rename(partPath, filePath); err != nil { // Atomically publish the completed download
+		log.Printf("Failed to finalize file for %s: %v", finalURL, err)
+		return downloadAttempt{Err: err}
 	}
-	defer out.Close() // Ensure file is closed after writing
 
-	if _, err := buf.WriteTo(out); err != nil { // Write buffer contents to file
-		log.Printf("Failed to write PDF to file for %s: %v", finalURL, err)
-		return false
+	digest := hex.EncodeToString(hasher.Sum(nil))
+	if err := os.WriteFile(filePath+".sha256", []byte(digest+"\n"), 0o644); err != nil {
+		log.Printf("Failed to write checksum for %s: %v", finalURL, err)
 	}
 
-	log.Printf("Successfully downloaded %d bytes: %s → %s", written, finalURL, filePath) // Log successful download
-	return true                                                                          // Return success
+	manifest.Set(finalURL, ManifestEntry{
+		Filename:     filePath,
+		SHA256:       digest,
+		Size:         totalSize,
+		ETag:         respHeaders.Get("ETag"),
+		LastModified: respHeaders.Get("Last-Modified"),
+		FetchedAt:    time.Now().Format(time.RFC3339),
+	})
+
+	log.Printf("Successfully downloaded %d bytes: %s → %s (sha256:%s)", totalSize, finalURL, filePath, digest)
+	return downloadAttempt{Written: totalSize}
+}
+
+// primeHashFromFile feeds the existing contents of path into hasher, so a
+// resumed download's digest covers bytes written in an earlier attempt too.
+func primeHashFromFile(path string, hasher hash.Hash) error {
+	existing, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer existing.Close()
+	_, err = io.Copy(hasher, existing)
+	return err
 }
 
 // Checks if a directory exists at the given path
@@ -210,4 +380,4 @@ func removeDuplicatesFromSlice(slice []string) []string {
 		}
 	}
 	return newReturnSlice // Return cleaned slice
-}
\ No newline at end of file
+}
@@ -0,0 +1,80 @@
+package main // Define the main package, shared with main.go
+
+import (
+	"encoding/json" // Encodes and decodes the manifest's JSON representation
+	"log"           // Offers logging capabilities to standard output or error streams
+	"os"            // Gives access to OS features, such as file and directory operations
+	"sync"          // Protects manifest state against concurrent workers
+)
+
+// ManifestEntry records everything we know about one previously downloaded
+// URL, enough to decide on a later run whether it needs fetching again.
+type ManifestEntry struct {
+	Filename     string `json:"filename"`      // Path the PDF was saved under
+	SHA256       string `json:"sha256"`        // Hex-encoded digest of the saved file
+	Size         int64  `json:"size"`          // Size of the saved file, in bytes
+	ETag         string `json:"etag"`          // ETag reported by the server, if any
+	LastModified string `json:"last_modified"` // Last-Modified header reported by the server, if any
+	FetchedAt    string `json:"fetched_at"`    // RFC3339 timestamp of when this entry was written
+}
+
+// Manifest is a URL -> ManifestEntry map persisted as JSON, so re-runs can
+// skip unchanged downloads using ETag/If-Modified-Since instead of relying
+// on filename existence alone.
+type Manifest struct {
+	path    string                   // File the manifest is loaded from and saved to
+	mu      sync.Mutex               // Guards entries against concurrent pool workers
+	entries map[string]ManifestEntry // URL -> entry
+}
+
+// LoadManifest reads path into a Manifest, starting empty if the file
+// doesn't exist yet or can't be parsed.
+func LoadManifest(path string) *Manifest {
+	manifest := &Manifest{path: path, entries: make(map[string]ManifestEntry)}
+
+	data, err := os.ReadFile(path)
+	if err != nil { // No manifest yet is expected on a first run
+		return manifest
+	}
+	if err := json.Unmarshal(data, &manifest.entries); err != nil {
+		log.Printf("Failed to parse manifest %s, starting fresh: %v", path, err)
+		manifest.entries = make(map[string]ManifestEntry)
+	}
+	return manifest
+}
+
+// Get returns the entry recorded for url, if any.
+func (m *Manifest) Get(url string) (ManifestEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[url]
+	return entry, ok
+}
+
+// Set records entry for url and persists the manifest to disk.
+func (m *Manifest) Set(url string, entry ManifestEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[url] = entry
+	m.save()
+}
+
+// save writes the manifest to its path, via a temp file and atomic rename so
+// a process killed mid-write can never leave a truncated manifest.json
+// behind. Callers must hold m.mu.
+func (m *Manifest) save() {
+	data, err := json.MarshalIndent(m.entries, "", "  ")
+	if err != nil {
+		log.Printf("Failed to marshal manifest: %v", err)
+		return
+	}
+
+	tmpPath := m.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		log.Printf("Failed to write manifest %s: %v", tmpPath, err)
+		return
+	}
+	if err := os.Rename(tmpPath, m.path); err != nil {
+		log.Printf("Failed to finalize manifest %s: %v", m.path, err)
+	}
+}
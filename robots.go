@@ -0,0 +1,185 @@
+package main // Define the main package, shared with main.go
+
+import (
+	"bufio"    // Reads robots.txt a line at a time
+	"io"       // Defines basic interfaces to I/O primitives, like Reader and Writer
+	"net/http" // Allows interaction with HTTP clients and servers
+	"net/url"  // Provides URL parsing, encoding, and query manipulation
+	"strconv"  // Parses the numeric value of a Crawl-delay directive
+	"strings"  // Contains utilities for string manipulation
+	"sync"     // Guards the per-host robots.txt cache against concurrent access
+	"time"     // Contains time-related functionality such as sleeping or timeouts
+)
+
+// robotsRule is a single Allow/Disallow path prefix from one robots.txt group.
+type robotsRule struct {
+	path  string // Path prefix the rule applies to
+	allow bool   // Whether matching this prefix allows or disallows the fetch
+}
+
+// robotsGroup holds the directives that apply to us for one host.
+type robotsGroup struct {
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+// allows reports whether path is permitted under this group, using the
+// longest-matching-prefix rule (ties resolve in favor of Allow).
+func (g *robotsGroup) allows(path string) bool {
+	bestLen := -1
+	bestAllow := true
+	for _, rule := range g.rules {
+		if rule.path == "" || !strings.HasPrefix(path, rule.path) {
+			continue
+		}
+		if len(rule.path) > bestLen || (len(rule.path) == bestLen && rule.allow) {
+			bestLen = len(rule.path)
+			bestAllow = rule.allow
+		}
+	}
+	return bestAllow
+}
+
+// RobotsPolicy consults each host's robots.txt before a fetch, caching the
+// parsed rules so a crawl doesn't re-fetch robots.txt on every page.
+type RobotsPolicy struct {
+	UserAgent string // Sent both when fetching robots.txt and when matching its User-agent groups
+
+	client *http.Client
+	mu     sync.Mutex
+	cache  map[string]*robotsGroup // host ("scheme://host") -> rules that apply to us
+}
+
+// NewRobotsPolicy builds a RobotsPolicy that identifies itself as userAgent.
+func NewRobotsPolicy(userAgent string) *RobotsPolicy {
+	return &RobotsPolicy{
+		UserAgent: userAgent,
+		client:    &http.Client{Timeout: 30 * time.Second},
+		cache:     make(map[string]*robotsGroup),
+	}
+}
+
+// Allowed reports whether rawURL may be fetched under its host's robots.txt.
+func (p *RobotsPolicy) Allowed(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return true // Let downstream validation reject the malformed URL instead
+	}
+	return p.groupFor(parsed).allows(parsed.Path)
+}
+
+// CrawlDelay returns the Crawl-delay directive for rawURL's host, or zero if
+// none was published.
+func (p *RobotsPolicy) CrawlDelay(rawURL string) time.Duration {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return 0
+	}
+	return p.groupFor(parsed).crawlDelay
+}
+
+// groupFor returns the cached robots.txt group for parsed's host, fetching
+// and parsing it on first use.
+func (p *RobotsPolicy) groupFor(parsed *url.URL) *robotsGroup {
+	host := parsed.Scheme + "://" + parsed.Host
+
+	p.mu.Lock()
+	if group, ok := p.cache[host]; ok {
+		p.mu.Unlock()
+		return group
+	}
+	p.mu.Unlock()
+
+	group := p.fetchGroup(host)
+
+	p.mu.Lock()
+	p.cache[host] = group
+	p.mu.Unlock()
+	return group
+}
+
+// fetchGroup downloads host's robots.txt and extracts the group applicable
+// to us, defaulting to allow-all if it's missing or unreadable.
+func (p *RobotsPolicy) fetchGroup(host string) *robotsGroup {
+	req, err := http.NewRequest(http.MethodGet, host+"/robots.txt", nil)
+	if err != nil {
+		return &robotsGroup{}
+	}
+	if p.UserAgent != "" {
+		req.Header.Set("User-Agent", p.UserAgent)
+	}
+
+	resp, err := p.client.Do(req) // A robots.txt we can't reach imposes no restrictions
+	if err != nil {
+		return &robotsGroup{}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &robotsGroup{}
+	}
+	return parseRobotsTxt(resp.Body, p.UserAgent)
+}
+
+// parseRobotsTxt reads a robots.txt body and returns the group of directives
+// that apply to userAgent, falling back to the "*" group when no specific
+// match is published.
+func parseRobotsTxt(body io.Reader, userAgent string) *robotsGroup {
+	groups := make(map[string]*robotsGroup)
+	var currentAgents []string
+	inRules := false // True once the current block has seen a Disallow/Allow/Crawl-delay line
+
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if inRules { // A new User-agent line after rules starts a fresh block
+				currentAgents = nil
+				inRules = false
+			}
+			agent := strings.ToLower(value)
+			currentAgents = append(currentAgents, agent)
+			if _, ok := groups[agent]; !ok {
+				groups[agent] = &robotsGroup{}
+			}
+		case "disallow":
+			inRules = true
+			for _, agent := range currentAgents {
+				groups[agent].rules = append(groups[agent].rules, robotsRule{path: value, allow: false})
+			}
+		case "allow":
+			inRules = true
+			for _, agent := range currentAgents {
+				groups[agent].rules = append(groups[agent].rules, robotsRule{path: value, allow: true})
+			}
+		case "crawl-delay":
+			inRules = true
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+				for _, agent := range currentAgents {
+					groups[agent].crawlDelay = time.Duration(seconds * float64(time.Second))
+				}
+			}
+		}
+	}
+
+	ua := strings.ToLower(userAgent)
+	for name, group := range groups { // Prefer a group naming us specifically over the wildcard
+		if name != "*" && ua != "" && strings.Contains(ua, name) {
+			return group
+		}
+	}
+	if group, ok := groups["*"]; ok {
+		return group
+	}
+	return &robotsGroup{}
+}
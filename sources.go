@@ -0,0 +1,158 @@
+package main // Define the main package, shared with main.go
+
+import (
+	"context"       // Bounds the lifetime of a single fetch dispatched through a Fetcher
+	"encoding/json" // Decodes IIIF-style collection manifests
+	"encoding/xml"  // Decodes sitemap.xml and sitemap index documents
+	"log"           // Offers logging capabilities to standard output or error streams
+	"net/http"      // Allows interaction with HTTP clients and servers
+	"net/url"       // Provides URL parsing, encoding, and query manipulation
+	"strings"       // Contains utilities for string manipulation
+)
+
+// maxSitemapDepth bounds how many levels of nested sitemap indexes we'll
+// follow, so a misconfigured or cyclic index can't recurse forever.
+const maxSitemapDepth = 5
+
+// isHTTPURL reports whether rawURL is an absolute http(s) URL. Sitemaps and
+// manifests are untrusted remote content, same as crawled pages, so entries
+// carrying any other scheme (file://, or none at all) are dropped rather
+// than handed to fetcherFor downstream.
+func isHTTPURL(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https")
+}
+
+// sitemapLoc mirrors the single `<loc>` child shared by both `<url>` and
+// `<sitemap>` entries.
+type sitemapLoc struct {
+	Loc string `xml:"loc"`
+}
+
+// sitemapURLSet is the root element of a plain sitemap.xml.
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	URLs    []sitemapLoc `xml:"url"`
+}
+
+// sitemapIndex is the root element of a sitemap index, which points at
+// other sitemaps instead of listing pages directly.
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	Sitemaps []sitemapLoc `xml:"sitemap"`
+}
+
+// FetchSitemapURLs downloads sitemapURL and returns every `.pdf` location it
+// (transitively, for a sitemap index) lists.
+func FetchSitemapURLs(sitemapURL string) []string {
+	return fetchSitemapURLs(sitemapURL, 0, make(map[string]bool))
+}
+
+// fetchSitemapURLs does the work for FetchSitemapURLs, tracking recursion
+// depth and visited sitemaps so a sitemap index can't loop forever.
+func fetchSitemapURLs(sitemapURL string, depth int, visited map[string]bool) []string {
+	if depth > maxSitemapDepth || visited[sitemapURL] {
+		return nil
+	}
+	visited[sitemapURL] = true
+
+	fetcher, err := fetcherFor(sitemapURL) // Resolve the right Fetcher for this URL's scheme, honoring configured headers
+	if err != nil {
+		log.Printf("Failed to fetch sitemap %s: %v", sitemapURL, err)
+		return nil
+	}
+	body, _, err := fetcher.Fetch(context.Background(), sitemapURL, http.Header{})
+	if err != nil {
+		log.Printf("Failed to fetch sitemap %s: %v", sitemapURL, err)
+		return nil
+	}
+	defer body.Close()
+
+	decoder := xml.NewDecoder(body) // Stream-decode so large sitemaps don't need to fit in memory at once
+	for {
+		token, err := decoder.Token()
+		if err != nil { // io.EOF (or any other error) means there was nothing usable to find
+			return nil
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "sitemapindex":
+			var index sitemapIndex
+			if err := decoder.DecodeElement(&index, &start); err != nil {
+				log.Printf("Failed to parse sitemap index %s: %v", sitemapURL, err)
+				return nil
+			}
+			var urls []string
+			for _, entry := range index.Sitemaps { // Recurse into every nested sitemap
+				urls = append(urls, fetchSitemapURLs(entry.Loc, depth+1, visited)...)
+			}
+			return urls
+		case "urlset":
+			var set sitemapURLSet
+			if err := decoder.DecodeElement(&set, &start); err != nil {
+				log.Printf("Failed to parse sitemap %s: %v", sitemapURL, err)
+				return nil
+			}
+			var urls []string
+			for _, entry := range set.URLs { // Only PDFs are relevant to the download pipeline
+				if isHTTPURL(entry.Loc) && strings.HasSuffix(strings.ToLower(entry.Loc), ".pdf") {
+					urls = append(urls, entry.Loc)
+				}
+			}
+			return urls
+		}
+	}
+}
+
+// iiifManifest is a minimal IIIF Presentation API collection manifest: a
+// list of sequences, each a list of canvases, each a list of images whose
+// resource `@id` is the URL of the underlying document.
+type iiifManifest struct {
+	Sequences []struct {
+		Canvases []struct {
+			Images []struct {
+				Resource struct {
+					ID string `json:"@id"`
+				} `json:"resource"`
+			} `json:"images"`
+		} `json:"canvases"`
+	} `json:"sequences"`
+}
+
+// FetchManifestURLs downloads manifestURL and returns every resource URL
+// referenced by its IIIF-style sequences/canvases/images tree.
+func FetchManifestURLs(manifestURL string) []string {
+	fetcher, err := fetcherFor(manifestURL) // Resolve the right Fetcher for this URL's scheme, honoring configured headers
+	if err != nil {
+		log.Printf("Failed to fetch manifest %s: %v", manifestURL, err)
+		return nil
+	}
+	body, _, err := fetcher.Fetch(context.Background(), manifestURL, http.Header{})
+	if err != nil {
+		log.Printf("Failed to fetch manifest %s: %v", manifestURL, err)
+		return nil
+	}
+	defer body.Close()
+
+	var manifest iiifManifest
+	if err := json.NewDecoder(body).Decode(&manifest); err != nil { // Stream-decode, same reasoning as sitemaps
+		log.Printf("Failed to parse manifest %s: %v", manifestURL, err)
+		return nil
+	}
+
+	var urls []string
+	for _, sequence := range manifest.Sequences {
+		for _, canvas := range sequence.Canvases {
+			for _, image := range canvas.Images {
+				if isHTTPURL(image.Resource.ID) {
+					urls = append(urls, image.Resource.ID)
+				}
+			}
+		}
+	}
+	return urls
+}